@@ -3,10 +3,45 @@ package atlas
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
 )
 
+// ErrNotFound is returned by BuildConfig when the requested build
+// configuration does not exist.
+var ErrNotFound = errors.New("atlas: resource not found")
+
+// BuildConfigNameRegexp and BuildNameRegexp are the patterns that Atlas
+// enforces server-side for build configuration slugs and individual
+// build names, respectively. They are exposed so that callers can
+// perform the same validation client-side before making a request.
+var (
+	BuildConfigNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9-_./]+$`)
+	BuildNameRegexp       = regexp.MustCompile(`^[a-zA-Z0-9-_]+$`)
+)
+
+// ValidateBuildConfigName returns an error if name is not a valid Atlas
+// build configuration slug.
+func ValidateBuildConfigName(name string) error {
+	if !BuildConfigNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid build configuration name: %q", name)
+	}
+	return nil
+}
+
+// ValidateBuildName returns an error if name is not a valid Atlas build
+// name.
+func ValidateBuildName(name string) error {
+	if !BuildNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid build name: %q", name)
+	}
+	return nil
+}
+
 // BuildConfig represents a Packer build configuration.
 type BuildConfig struct {
 	// User is the namespace under which the build config lives
@@ -27,6 +62,40 @@ type BuildConfigVersion struct {
 
 	// Builds is the list of builds that this version supports.
 	Builds []BuildConfigBuild
+
+	// Message is an optional human-readable message describing this
+	// version, such as a commit message, that is attached to the
+	// version for display in the Atlas UI.
+	Message string `json:"message,omitempty"`
+
+	// VCS contains optional version control provenance for this
+	// version, such as the repository URL, branch, and commit that
+	// produced it.
+	VCS *BuildConfigVCS `json:"vcs,omitempty"`
+
+	// Metadata is an optional set of arbitrary key/value pairs to
+	// attach to this version.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Create, if true, causes UploadBuildConfigVersion to create the
+	// build configuration first if it does not already exist, instead
+	// of requiring it to have been created out-of-band. This field is
+	// not sent to the API.
+	Create bool `json:"-"`
+}
+
+// BuildConfigVCS contains version control metadata that can be attached
+// to an uploaded BuildConfigVersion.
+type BuildConfigVCS struct {
+	// URL is the URL of the VCS repository, such as a GitHub URL.
+	URL string `json:"url,omitempty"`
+
+	// Branch is the VCS branch that this version was built from.
+	Branch string `json:"branch,omitempty"`
+
+	// Commit is the VCS commit (or revision) that this version was
+	// built from.
+	Commit string `json:"commit,omitempty"`
 }
 
 // BuildConfigBuild is a single build that is present in an uploaded
@@ -38,6 +107,30 @@ type BuildConfigBuild struct {
 	// Type is the type of builder that this build needs to run on,
 	// such as "amazon-ebs" or "qemu".
 	Type string `json:"type"`
+
+	// Artifact, if true, indicates that this build produces an
+	// artifact that Atlas should store and make available for
+	// retrieval.
+	Artifact bool `json:"artifact,omitempty"`
+
+	// Vars is the list of template variables used by this build. Vars
+	// marked Sensitive are masked by Atlas and are not echoed back in
+	// logs or build output.
+	Vars []BuildVar `json:"vars,omitempty"`
+}
+
+// BuildVar is a single template variable associated with a
+// BuildConfigBuild.
+type BuildVar struct {
+	// Key is the name of the variable.
+	Key string `json:"key"`
+
+	// Value is the value of the variable.
+	Value string `json:"value"`
+
+	// Sensitive, if true, indicates that Value should be treated as
+	// sensitive: masked server-side and not echoed back in logs.
+	Sensitive bool `json:"sensitive"`
 }
 
 // BuildConfig gets a single build configuration by user and name.
@@ -48,7 +141,14 @@ func (c *Client) BuildConfig(user, name string) (*BuildConfig, error) {
 		return nil, err
 	}
 
-	response, err := checkResp(c.HTTPClient.Do(request))
+	resp, err := c.HTTPClient.Do(request)
+	if err == nil && resp.StatusCode == http.StatusNotFound {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+
+	response, err := checkResp(resp, err)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +163,10 @@ func (c *Client) BuildConfig(user, name string) (*BuildConfig, error) {
 
 // CreateBuildConfig creates a new build configuration.
 func (c *Client) CreateBuildConfig(user, name string) error {
+	if err := ValidateBuildConfigName(name); err != nil {
+		return err
+	}
+
 	endpoint := "/api/v1/packer/build-configurations"
 
 	body, err := json.Marshal(&bcWrapper{
@@ -89,17 +193,59 @@ func (c *Client) CreateBuildConfig(user, name string) error {
 	return err
 }
 
+// CreateOrUpdateBuildConfig ensures that a build configuration with the
+// given user and name exists, creating it if necessary. It is idempotent:
+// if the build configuration already exists, it is left untouched.
+func (c *Client) CreateOrUpdateBuildConfig(user, name string) error {
+	_, err := c.BuildConfig(user, name)
+	if err == nil {
+		return nil
+	}
+	if err != ErrNotFound {
+		return err
+	}
+
+	return c.CreateBuildConfig(user, name)
+}
+
 // UploadBuildConfigVersion creates a single build configuration version
 // and uploads the template associated with it.
 //
 // Actual API: "Create Build Config Version"
 func (c *Client) UploadBuildConfigVersion(
 	v *BuildConfigVersion, tpl io.Reader, size int64) error {
+	return c.UploadBuildConfigVersionWithOptions(v, tpl, size, nil)
+}
+
+// UploadBuildConfigVersionWithOptions behaves like UploadBuildConfigVersion
+// but allows the caller to customize how the template is uploaded, such as
+// reporting progress or tuning retry behavior. A nil opts is equivalent to
+// calling UploadBuildConfigVersion.
+func (c *Client) UploadBuildConfigVersionWithOptions(
+	v *BuildConfigVersion, tpl io.Reader, size int64, opts *UploadOptions) error {
+	if err := ValidateBuildConfigName(v.Name); err != nil {
+		return err
+	}
+	for _, b := range v.Builds {
+		if err := ValidateBuildName(b.Name); err != nil {
+			return err
+		}
+	}
+
+	if v.Create {
+		if err := c.CreateOrUpdateBuildConfig(v.User, v.Name); err != nil {
+			return err
+		}
+	}
+
 	endpoint := fmt.Sprintf("/api/v1/packer/build-configurations/%s/%s/versions",
 		v.User, v.Name)
 
 	var bodyData bcCreateWrapper
 	bodyData.Version.Builds = v.Builds
+	bodyData.Version.Message = v.Message
+	bodyData.Version.VCS = v.VCS
+	bodyData.Version.Metadata = v.Metadata
 	body, err := json.Marshal(bodyData)
 	if err != nil {
 		return err
@@ -125,7 +271,7 @@ func (c *Client) UploadBuildConfigVersion(
 		return err
 	}
 
-	if err := c.putFile(data.UploadPath, tpl, size); err != nil {
+	if err := c.putFile(data.UploadPath, tpl, size, opts); err != nil {
 		return err
 	}
 
@@ -145,6 +291,9 @@ type bcCreate struct {
 // bcCreateWrapper is the wrapper for creating a build config.
 type bcCreateWrapper struct {
 	Version struct {
-		Builds []BuildConfigBuild `json:"builds"`
+		Builds   []BuildConfigBuild `json:"builds"`
+		Message  string             `json:"message,omitempty"`
+		VCS      *BuildConfigVCS    `json:"vcs,omitempty"`
+		Metadata map[string]string  `json:"metadata,omitempty"`
 	} `json:"version"`
 }
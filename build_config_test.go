@@ -0,0 +1,130 @@
+package atlas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestValidateBuildConfigName(t *testing.T) {
+	cases := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"my-org/my-build-config", false},
+		{"simple-name", false},
+		{"name_with.dots-and-dashes/sub", false},
+		{"", true},
+		{"invalid name with spaces", true},
+		{"invalid!name", true},
+	}
+
+	for _, tc := range cases {
+		err := ValidateBuildConfigName(tc.name)
+		if tc.wantErr && err == nil {
+			t.Errorf("ValidateBuildConfigName(%q) = nil, want error", tc.name)
+		}
+		if !tc.wantErr && err != nil {
+			t.Errorf("ValidateBuildConfigName(%q) = %v, want nil", tc.name, err)
+		}
+	}
+}
+
+func TestValidateBuildName(t *testing.T) {
+	cases := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"amazon-ebs", false},
+		{"qemu_build-1", false},
+		{"", true},
+		{"has a space", true},
+		{"has/a/slash", true},
+		{"has.a.dot", true},
+	}
+
+	for _, tc := range cases {
+		err := ValidateBuildName(tc.name)
+		if tc.wantErr && err == nil {
+			t.Errorf("ValidateBuildName(%q) = nil, want error", tc.name)
+		}
+		if !tc.wantErr && err != nil {
+			t.Errorf("ValidateBuildName(%q) = %v, want nil", tc.name, err)
+		}
+	}
+}
+
+// testClient returns a Client pointed at server.
+func testClient(t *testing.T, server *httptest.Server) *Client {
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %s", err)
+	}
+	return &Client{URL: u, HTTPClient: server.Client()}
+}
+
+func TestCreateOrUpdateBuildConfig_Exists(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/packer/build-configurations/hashicorp/existing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"username":"hashicorp","name":"existing"}`))
+	})
+	mux.HandleFunc("/api/v1/packer/build-configurations", func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("CreateBuildConfig should not be called when the build config already exists")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := testClient(t, server)
+	if err := c.CreateOrUpdateBuildConfig("hashicorp", "existing"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestCreateOrUpdateBuildConfig_NotFoundCreates(t *testing.T) {
+	var created bool
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/packer/build-configurations/hashicorp/new-config", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/v1/packer/build-configurations", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Fatalf("unexpected method %s", r.Method)
+		}
+		created = true
+		w.WriteHeader(http.StatusOK)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := testClient(t, server)
+	if err := c.CreateOrUpdateBuildConfig("hashicorp", "new-config"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !created {
+		t.Fatal("expected CreateBuildConfig to be called after a 404")
+	}
+}
+
+func TestCreateOrUpdateBuildConfig_OtherErrorPropagates(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/packer/build-configurations/hashicorp/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/api/v1/packer/build-configurations", func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("CreateBuildConfig should not be called on a non-404 error")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := testClient(t, server)
+	err := c.CreateOrUpdateBuildConfig("hashicorp", "broken")
+	if err == nil {
+		t.Fatal("expected error to propagate")
+	}
+	if err == ErrNotFound {
+		t.Fatal("a 500 must not be reported as ErrNotFound")
+	}
+}
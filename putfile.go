@@ -0,0 +1,204 @@
+package atlas
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+)
+
+// defaultPutFileChunkSize is the buffer size used to stream a template
+// upload when the caller does not specify UploadOptions.ChunkSize.
+const defaultPutFileChunkSize = 4096
+
+// defaultPutFileMaxRetries is the number of retry attempts used to
+// stream a template upload when the caller does not specify
+// UploadOptions.MaxRetries.
+const defaultPutFileMaxRetries = 3
+
+// UploadOptions customizes how UploadBuildConfigVersionWithOptions streams
+// the template to Atlas.
+type UploadOptions struct {
+	// ProgressFn, if set, is called as bytes are uploaded, with the
+	// cumulative number of bytes uploaded so far and the total size of
+	// the upload.
+	ProgressFn func(uploaded, total int64)
+
+	// ChunkSize is the size, in bytes, of the buffer used to stream the
+	// upload. Defaults to 4096 if not set.
+	ChunkSize int64
+
+	// MaxRetries is the number of times to retry the upload if it fails
+	// with a transient error, such as a dropped connection or a 5xx
+	// response. A negative value defaults to 3; 0 means no retries are
+	// attempted. A nil *UploadOptions (as used by the UploadBuildConfigVersion
+	// shim) also defaults to 3 retries.
+	MaxRetries int
+
+	// Context, if set, is used to cancel the upload.
+	Context context.Context
+
+	// ReaderFactory, if set, is called to obtain a fresh reader
+	// positioned at the start of the upload when a retry is needed and
+	// the original reader does not implement io.ReaderAt.
+	ReaderFactory func() (io.Reader, error)
+}
+
+// putFile uploads r to the presigned URL in rawPath, retrying transient
+// failures according to opts.
+func (c *Client) putFile(rawPath string, r io.Reader, size int64, opts *UploadOptions) error {
+	// A nil opts means the caller didn't ask for any particular
+	// behavior, so it gets the same defaults as an explicit &UploadOptions{}
+	// would for everything except MaxRetries: there, 0 is a valid,
+	// explicit "don't retry" request, so only nil or a negative
+	// MaxRetries means "use the default retry count".
+	maxRetries := defaultPutFileMaxRetries
+	if opts != nil {
+		maxRetries = opts.MaxRetries
+		if opts.MaxRetries < 0 {
+			maxRetries = defaultPutFileMaxRetries
+		}
+	}
+
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultPutFileChunkSize
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	readerAt, canSeek := r.(io.ReaderAt)
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			var err error
+			if canSeek {
+				r = io.NewSectionReader(readerAt, 0, size)
+			} else if opts.ReaderFactory != nil {
+				r, err = opts.ReaderFactory()
+				if err != nil {
+					return err
+				}
+			} else {
+				// No way to restart the upload from the beginning.
+				return lastErr
+			}
+
+			time.Sleep(putFileBackoff(attempt))
+		}
+
+		err := c.putFileOnce(ctx, rawPath, r, size, chunkSize, opts.ProgressFn)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			// The context was canceled or its deadline expired; honor
+			// that immediately rather than retrying.
+			return err
+		}
+		if !isTransientPutFileError(err) {
+			return err
+		}
+
+		lastErr = err
+	}
+
+	return lastErr
+}
+
+// putFileOnce performs a single attempt at uploading r to rawPath.
+func (c *Client) putFileOnce(
+	ctx context.Context,
+	rawPath string,
+	r io.Reader,
+	size int64,
+	chunkSize int64,
+	progressFn func(uploaded, total int64)) error {
+	body := &progressReader{
+		r:          bufio.NewReaderSize(r, int(chunkSize)),
+		total:      size,
+		progressFn: progressFn,
+	}
+
+	request, err := http.NewRequest("PUT", rawPath, body)
+	if err != nil {
+		return err
+	}
+	request = request.WithContext(ctx)
+	request.ContentLength = size
+
+	resp, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return &putFileError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &putFileError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// putFileBackoff returns the delay to wait before the given retry
+// attempt (1-indexed), using a simple exponential backoff. It is a
+// variable so tests can shorten it.
+var putFileBackoff = func(attempt int) time.Duration {
+	return time.Duration(math.Pow(2, float64(attempt))) * time.Second
+}
+
+// putFileError represents a failed putFile attempt, either because the
+// request itself failed (Err) or because the server returned a non-2xx
+// status (StatusCode).
+type putFileError struct {
+	Err        error
+	StatusCode int
+}
+
+func (e *putFileError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("atlas: upload failed: %s", e.Err)
+	}
+	return fmt.Sprintf("atlas: upload failed with status %d", e.StatusCode)
+}
+
+// isTransientPutFileError reports whether err represents a failure that
+// is worth retrying: a connection-level error or a 5xx response.
+func isTransientPutFileError(err error) bool {
+	pfe, ok := err.(*putFileError)
+	if !ok {
+		return false
+	}
+	return pfe.Err != nil || pfe.StatusCode >= 500
+}
+
+// progressReader wraps an io.Reader, invoking progressFn with the
+// cumulative number of bytes read after each Read call.
+type progressReader struct {
+	r          io.Reader
+	total      int64
+	uploaded   int64
+	progressFn func(uploaded, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.uploaded += int64(n)
+		if p.progressFn != nil {
+			p.progressFn(p.uploaded, p.total)
+		}
+	}
+	return n, err
+}
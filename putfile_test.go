@@ -0,0 +1,129 @@
+package atlas
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func withZeroBackoff(t *testing.T) {
+	orig := putFileBackoff
+	putFileBackoff = func(attempt int) time.Duration { return 0 }
+	t.Cleanup(func() { putFileBackoff = orig })
+}
+
+func TestPutFile_RetriesOn5xx(t *testing.T) {
+	withZeroBackoff(t)
+
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client()}
+	data := []byte("hello world")
+	if err := c.putFile(server.URL, bytes.NewReader(data), int64(len(data)), &UploadOptions{MaxRetries: 3}); err != nil {
+		t.Fatalf("expected success after retries, got error: %s", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestPutFile_NilOptsRetriesWithDefault(t *testing.T) {
+	withZeroBackoff(t)
+
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client()}
+	data := []byte("hello world")
+	if err := c.putFile(server.URL, bytes.NewReader(data), int64(len(data)), nil); err != nil {
+		t.Fatalf("expected nil opts to retry with the default MaxRetries, got error: %s", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestPutFile_NoRetryOn4xx(t *testing.T) {
+	withZeroBackoff(t)
+
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client()}
+	data := []byte("hello world")
+	if err := c.putFile(server.URL, bytes.NewReader(data), int64(len(data)), &UploadOptions{MaxRetries: 3}); err == nil {
+		t.Fatal("expected error for 4xx response")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected no retries on 4xx, got %d attempts", attempts)
+	}
+}
+
+func TestPutFile_MaxRetriesZeroMeansNoRetries(t *testing.T) {
+	withZeroBackoff(t)
+
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client()}
+	data := []byte("hello world")
+	if err := c.putFile(server.URL, bytes.NewReader(data), int64(len(data)), &UploadOptions{MaxRetries: 0}); err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected exactly 1 attempt with MaxRetries=0, got %d", attempts)
+	}
+}
+
+func TestPutFile_ProgressCallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client()}
+	data := []byte("hello world")
+	var lastUploaded, lastTotal int64
+	err := c.putFile(server.URL, bytes.NewReader(data), int64(len(data)), &UploadOptions{
+		ProgressFn: func(uploaded, total int64) {
+			lastUploaded = uploaded
+			lastTotal = total
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if lastUploaded != int64(len(data)) {
+		t.Fatalf("expected final progress of %d bytes, got %d", len(data), lastUploaded)
+	}
+	if lastTotal != int64(len(data)) {
+		t.Fatalf("expected total of %d bytes, got %d", len(data), lastTotal)
+	}
+}